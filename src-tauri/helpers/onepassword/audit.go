@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redactedPlaceholder = "***redacted***"
+
+// AuditRecord is one JSON-lines entry written by AuditLogger. It never
+// carries plaintext secret material: methods that return secret values
+// (secrets.resolve, items.get) are redacted down to a SHA-256
+// fingerprint so operators can correlate a leak without the log itself
+// becoming a new way to leak it.
+type AuditRecord struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Method           string    `json:"method"`
+	TokenFingerprint string    `json:"token_fingerprint"`
+	VaultID          string    `json:"vault_id,omitempty"`
+	ItemID           string    `json:"item_id,omitempty"`
+	LatencyMS        int64     `json:"latency_ms"`
+	Ok               bool      `json:"ok"`
+	Error            string    `json:"error,omitempty"`
+	ErrorCode        string    `json:"error_code,omitempty"`
+	Redacted         bool      `json:"redacted,omitempty"`
+	Value            string    `json:"value,omitempty"`
+	ValueFingerprint string    `json:"value_fingerprint,omitempty"`
+}
+
+// AuditLogger appends AuditRecords as JSON-lines to a rotating file.
+type AuditLogger struct {
+	mu          sync.Mutex
+	path        string
+	f           *os.File
+	size        int64
+	maxBytes    int64
+	dailyRotate bool
+	fsync       bool
+	openedDay   string
+}
+
+// newAuditLogger opens (or creates) the audit log at path. maxBytes <= 0
+// disables size-based rotation; dailyRotate additionally rotates the
+// file at the first write after local midnight.
+func newAuditLogger(path string, maxBytes int64, dailyRotate bool, fsync bool) (*AuditLogger, error) {
+	a := &AuditLogger{
+		path:        path,
+		maxBytes:    maxBytes,
+		dailyRotate: dailyRotate,
+		fsync:       fsync,
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *AuditLogger) open() error {
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+	a.f = f
+	a.size = info.Size()
+	a.openedDay = time.Now().Format("2006-01-02")
+	return nil
+}
+
+// Log appends record to the audit log, rotating first if the configured
+// size or daily policy requires it.
+func (a *AuditLogger) Log(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.rotateIfNeededLocked(int64(len(data))); err != nil {
+		return err
+	}
+
+	n, err := a.f.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	a.size += int64(n)
+
+	if a.fsync {
+		if err := a.f.Sync(); err != nil {
+			return fmt.Errorf("failed to fsync audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotateIfNeededLocked renames the current file aside with a timestamp
+// suffix and opens a fresh one. Callers must hold a.mu.
+func (a *AuditLogger) rotateIfNeededLocked(nextWrite int64) error {
+	today := time.Now().Format("2006-01-02")
+	needsRotate := (a.maxBytes > 0 && a.size+nextWrite > a.maxBytes) ||
+		(a.dailyRotate && today != a.openedDay)
+	if !needsRotate {
+		return nil
+	}
+
+	if err := a.f.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log before rotation: %w", err)
+	}
+
+	rotatedPath := fmt.Sprintf("%s.%s", a.path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(a.path, rotatedPath); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	return a.open()
+}
+
+// Close flushes and closes the underlying file.
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.f.Close()
+}
+
+// redactSecretValue fingerprints the secret material in a response's
+// result for secrets.resolve, items.get, and kv.read, so the caller can
+// build an AuditRecord without ever storing the plaintext value.
+func redactSecretValue(method string, result interface{}) (fingerprint string, redacted bool) {
+	data, err := json.Marshal(result)
+	if err != nil || len(data) == 0 {
+		return "", false
+	}
+
+	switch method {
+	case "secrets.resolve":
+		var payload struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(data, &payload); err == nil && payload.Value != "" {
+			return sha256Hex(payload.Value), true
+		}
+	case "items.get":
+		var payload struct {
+			Fields []struct {
+				Value string `json:"value"`
+			} `json:"fields"`
+		}
+		if err := json.Unmarshal(data, &payload); err == nil && len(payload.Fields) > 0 {
+			var concat strings.Builder
+			for _, field := range payload.Fields {
+				concat.WriteString(field.Value)
+			}
+			return sha256Hex(concat.String()), true
+		}
+	case "kv.read":
+		var payload struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.Unmarshal(data, &payload); err == nil && len(payload.Data) > 0 {
+			keys := make([]string, 0, len(payload.Data))
+			for k := range payload.Data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var concat strings.Builder
+			for _, k := range keys {
+				concat.WriteString(payload.Data[k])
+			}
+			return sha256Hex(concat.String()), true
+		}
+	}
+	return "", false
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
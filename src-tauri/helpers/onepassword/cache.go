@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	cachePathEnv       = "TAIRSEACH_CACHE_PATH"
+	cachePassphraseEnv = "TAIRSEACH_CACHE_PASSPHRASE"
+
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	saltLen       = 16
+)
+
+// cachedCall runs call, caching its successful result on disk (encrypted
+// with a passphrase-derived key) and transparently serving a cached copy
+// per opts.CacheTTL / opts.OfflineOk. When caching isn't configured it
+// behaves exactly like calling call directly.
+func cachedCall(id, method string, opts CacheOptions, keyParts []string, errPrefix string, call func() (interface{}, error)) Response {
+	dir, passphrase := resolveCacheConfig(opts)
+	if dir == "" || passphrase == "" {
+		result, err := call()
+		if err != nil {
+			return errorResponse(id, fmt.Sprintf("%s: %v", errPrefix, err))
+		}
+		return successResponse(id, result)
+	}
+
+	dc := &diskCache{dir: dir, passphrase: passphrase}
+	key := cacheKey(method, keyParts)
+	ttl := time.Duration(opts.CacheTTL) * time.Second
+
+	if ttl > 0 {
+		if data, age, ok := dc.Get(key); ok && age <= ttl {
+			resp := successResponse(id, json.RawMessage(data))
+			resp.CacheHit = true
+			return resp
+		}
+	}
+
+	result, callErr := call()
+	if callErr != nil {
+		if opts.OfflineOk {
+			if data, _, ok := dc.Get(key); ok {
+				resp := successResponse(id, json.RawMessage(data))
+				resp.CacheHit = true
+				resp.Stale = true
+				return resp
+			}
+		}
+		return errorResponse(id, fmt.Sprintf("%s: %v", errPrefix, callErr))
+	}
+
+	if data, err := json.Marshal(result); err == nil {
+		_ = dc.Put(key, data)
+	}
+	return successResponse(id, result)
+}
+
+// resolveCacheConfig prefers per-request overrides over the
+// TAIRSEACH_CACHE_PATH / TAIRSEACH_CACHE_PASSPHRASE env vars. Caching is
+// disabled unless both a directory and a passphrase are available.
+func resolveCacheConfig(opts CacheOptions) (dir, passphrase string) {
+	dir = opts.CachePath
+	if dir == "" {
+		dir = os.Getenv(cachePathEnv)
+	}
+	passphrase = opts.CachePassphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(cachePassphraseEnv)
+	}
+	return dir, passphrase
+}
+
+// cacheKey derives a filesystem-safe cache key from a method name and its
+// identifying parameters (vault/item IDs, a secret reference, ...).
+func cacheKey(method string, parts []string) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// diskCache stores encrypted cache entries as one file per key under dir.
+// Each entry is sealed with AES-256-GCM using a key derived from
+// passphrase via Argon2id, with a fresh random salt per entry.
+type diskCache struct {
+	dir        string
+	passphrase string
+}
+
+// diskCacheEntry is the on-disk (JSON, pre-encryption-metadata) record.
+type diskCacheEntry struct {
+	Salt       []byte    `json:"salt"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+	StoredAt   time.Time `json:"stored_at"`
+}
+
+func (c *diskCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get returns the decrypted plaintext for key and how long ago it was
+// stored. ok is false if there is no entry or it could not be decrypted
+// (e.g. wrong passphrase).
+func (c *diskCache) Get(key string) (plaintext []byte, age time.Duration, ok bool) {
+	raw, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, 0, false
+	}
+
+	var entry diskCacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, 0, false
+	}
+
+	gcm, err := c.aeadFor(entry.Salt)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	plaintext, err = gcm.Open(nil, entry.Nonce, entry.Ciphertext, nil)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	return plaintext, time.Since(entry.StoredAt), true
+}
+
+// Put encrypts plaintext and writes it to key's cache file, creating dir
+// if needed.
+func (c *diskCache) Put(key string, plaintext []byte) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	gcm, err := c.aeadFor(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	entry := diskCacheEntry{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+		StoredAt:   time.Now(),
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.entryPath(key), data, 0o600)
+}
+
+func (c *diskCache) aeadFor(salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(c.passphrase), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+)
+
+// cachedClient pairs a live SDK client with its last-access time so the
+// cache can evict idle entries.
+type cachedClient struct {
+	token    string
+	client   *onepassword.Client
+	lastUsed time.Time
+	listElem *list.Element
+}
+
+// clientCache is an LRU cache of *onepassword.Client keyed by service
+// account token, so daemon mode can reuse a client (and its WASM
+// runtime) across many requests instead of paying cold-start cost on
+// every call. Entries idle longer than idleTTL are evicted lazily on
+// the next Get.
+type clientCache struct {
+	mu      sync.Mutex
+	size    int
+	idleTTL time.Duration
+	entries map[string]*cachedClient
+	lru     *list.List // front = most recently used
+}
+
+func newClientCache(size int, idleTTL time.Duration) *clientCache {
+	return &clientCache{
+		size:    size,
+		idleTTL: idleTTL,
+		entries: make(map[string]*cachedClient),
+		lru:     list.New(),
+	}
+}
+
+// Get returns a client for token, initializing and caching a new one if
+// none is cached or the cached entry has gone idle.
+func (c *clientCache) Get(ctx context.Context, token string) (*onepassword.Client, error) {
+	c.mu.Lock()
+	c.evictIdleLocked()
+	if entry, ok := c.entries[token]; ok {
+		entry.lastUsed = time.Now()
+		c.lru.MoveToFront(entry.listElem)
+		client := entry.client
+		c.mu.Unlock()
+		return client, nil
+	}
+	c.mu.Unlock()
+
+	client, err := onepassword.NewClient(
+		ctx,
+		onepassword.WithServiceAccountToken(token),
+		onepassword.WithIntegrationInfo("Tairseach", "0.1.0"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another goroutine may have raced us to initialize the same token.
+	if entry, ok := c.entries[token]; ok {
+		entry.lastUsed = time.Now()
+		c.lru.MoveToFront(entry.listElem)
+		return entry.client, nil
+	}
+
+	entry := &cachedClient{token: token, client: client, lastUsed: time.Now()}
+	entry.listElem = c.lru.PushFront(entry)
+	c.entries[token] = entry
+	c.evictOverflowLocked()
+
+	return client, nil
+}
+
+// evictIdleLocked drops entries that haven't been used within idleTTL.
+// Callers must hold c.mu.
+func (c *clientCache) evictIdleLocked() {
+	if c.idleTTL <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-c.idleTTL)
+	for elem := c.lru.Back(); elem != nil; {
+		entry := elem.Value.(*cachedClient)
+		prev := elem.Prev()
+		if entry.lastUsed.Before(cutoff) {
+			c.removeLocked(entry)
+		}
+		elem = prev
+	}
+}
+
+// evictOverflowLocked drops least-recently-used entries until the cache
+// is back under its configured size. Callers must hold c.mu.
+func (c *clientCache) evictOverflowLocked() {
+	for c.size > 0 && len(c.entries) > c.size {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			return
+		}
+		c.removeLocked(oldest.Value.(*cachedClient))
+	}
+}
+
+func (c *clientCache) removeLocked(entry *cachedClient) {
+	c.lru.Remove(entry.listElem)
+	delete(c.entries, entry.token)
+}
+
+// Close releases every cached entry. The SDK client type does not expose
+// an explicit Close, so this simply drops our references so the WASM
+// runtimes can be garbage collected.
+func (c *clientCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cachedClient)
+	c.lru.Init()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultClientCacheSize bounds how many distinct service-account
+	// tokens keep a live *onepassword.Client around at once.
+	defaultClientCacheSize = 8
+	// defaultClientIdleTTL is how long a cached client may sit unused
+	// before it is evicted and its WASM runtime torn down.
+	defaultClientIdleTTL = 15 * time.Minute
+	// defaultWorkerCount bounds how many requests are handled
+	// concurrently so one slow vault call can't starve the others.
+	defaultWorkerCount = 8
+)
+
+// shutdownMethod is the control method daemon callers send to stop the
+// process gracefully instead of sending SIGTERM.
+const shutdownMethod = "shutdown"
+
+// runDaemon keeps the process alive, reading newline-delimited JSON
+// requests from in and writing one newline-delimited JSON response per
+// line to out. Requests are dispatched to a bounded worker pool so a
+// slow vault call doesn't block the rest of the stream; responses may
+// therefore complete out of order, which is why callers should set
+// Request.ID and match it against Response.ID.
+func runDaemon(in *os.File, out *os.File, policy *Policy, audit *AuditLogger) {
+	cache := newClientCache(defaultClientCacheSize, defaultClientIdleTTL)
+	defer cache.Close()
+
+	var outMu sync.Mutex
+	writeLocked := func(resp Response) {
+		outMu.Lock()
+		defer outMu.Unlock()
+		writeResponseTo(out, resp)
+	}
+
+	jobs := make(chan Request)
+	var wg sync.WaitGroup
+	for i := 0; i < defaultWorkerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				writeLocked(handleDaemonRequest(cache, policy, audit, req))
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			writeLocked(Response{Ok: false, Error: fmt.Sprintf("Invalid JSON: %v", err)})
+			continue
+		}
+
+		if req.Method == shutdownMethod {
+			writeLocked(Response{ID: req.ID, Ok: true, Result: "shutting down"})
+			close(jobs)
+			wg.Wait()
+			return
+		}
+
+		jobs <- req
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// handleDaemonRequest validates a request, resolves a cached (or freshly
+// initialized) client for its token, and dispatches it. Unlike runOnce,
+// client initialization is amortized across requests via the cache.
+func handleDaemonRequest(cache *clientCache, policy *Policy, audit *AuditLogger, req Request) Response {
+	if req.Token == "" {
+		return Response{ID: req.ID, Ok: false, Error: "Missing token"}
+	}
+
+	start := time.Now()
+
+	if err := authorize(policy, req); err != nil {
+		resp := Response{ID: req.ID, Ok: false, Error: err.Error(), ErrorCode: "forbidden"}
+		logAudit(audit, policy, req, resp, start)
+		return resp
+	}
+
+	client, err := cache.Get(context.Background(), req.Token)
+	if err != nil {
+		return Response{ID: req.ID, Ok: false, Error: fmt.Sprintf("Failed to initialize 1Password client: %v", err)}
+	}
+
+	resp := dispatch(context.Background(), client, req)
+	logAudit(audit, policy, req, resp, start)
+	return resp
+}
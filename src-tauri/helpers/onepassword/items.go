@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/1password/onepassword-sdk-go"
+)
+
+// ItemFieldInput is one entry of ItemInput.Fields. ID is only meaningful
+// on items.update, where it lets a caller overwrite a specific existing
+// field (e.g. to rotate a concealed value) rather than replacing the
+// whole field list positionally.
+type ItemFieldInput struct {
+	ID      string `json:"id,omitempty"`
+	Label   string `json:"label"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Purpose string `json:"purpose,omitempty"`
+	Section string `json:"section,omitempty"`
+}
+
+// ItemSectionInput is one entry of ItemInput.Sections.
+type ItemSectionInput struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// ItemURLInput is one entry of ItemInput.URLs. Primary controls whether
+// the translated onepassword.Website autofills on any matching page or
+// only on an exact domain match.
+type ItemURLInput struct {
+	Href    string `json:"href"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// ItemInput is the normalized item schema shared by items.create and
+// items.update, so both routes get consistent field-type handling
+// (concealed vs. string vs. otp) and consistent error shapes. File
+// fields aren't representable here; they require file content and go
+// through a separate files API.
+type ItemInput struct {
+	Title    string             `json:"title"`
+	Category string             `json:"category"`
+	Tags     []string           `json:"tags,omitempty"`
+	Fields   []ItemFieldInput   `json:"fields,omitempty"`
+	Sections []ItemSectionInput `json:"sections,omitempty"`
+	URLs     []ItemURLInput     `json:"urls,omitempty"`
+	Notes    string             `json:"notes,omitempty"`
+}
+
+// ItemsUpdateParams for items.update
+type ItemsUpdateParams struct {
+	VaultID string    `json:"vault_id"`
+	ItemID  string    `json:"item_id"`
+	Item    ItemInput `json:"item"`
+	Version uint32    `json:"version,omitempty"`
+}
+
+// ItemsDeleteParams for items.delete
+type ItemsDeleteParams struct {
+	VaultID string `json:"vault_id"`
+	ItemID  string `json:"item_id"`
+}
+
+// ItemsShareParams for items.share. ExpiresInSeconds is rounded up to
+// the nearest duration the SDK supports (one hour through thirty days);
+// when unset the account's default expiry is used. MaxViews of exactly
+// 1 maps to a one-time-only link; the SDK otherwise leaves view limits
+// to the account's sharing policy.
+type ItemsShareParams struct {
+	VaultID          string `json:"vault_id"`
+	ItemID           string `json:"item_id"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds,omitempty"`
+	MaxViews         int    `json:"max_views,omitempty"`
+}
+
+func handleItemsCreate(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p ItemCreateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for items.create: %v", err))
+	}
+
+	if p.VaultID == "" {
+		return errorResponse(id, "Missing vault_id parameter")
+	}
+	if err := validateItemInput(p.Item); err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	createParams, err := normalizeItemInput(p.VaultID, p.Item)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	item, err := client.Items().Create(ctx, createParams)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to create item: %v", err))
+	}
+	return successResponse(id, item)
+}
+
+func handleItemsUpdate(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p ItemsUpdateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for items.update: %v", err))
+	}
+
+	if p.VaultID == "" || p.ItemID == "" {
+		return errorResponse(id, "Missing vault_id or item_id parameter")
+	}
+	if err := validateItemInput(p.Item); err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	existing, err := client.Items().Get(ctx, p.VaultID, p.ItemID)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to get item: %v", err))
+	}
+
+	if p.Version != 0 && existing.Version != p.Version {
+		return Response{
+			ID:        id,
+			Ok:        false,
+			Error:     fmt.Sprintf("Item %s has version %d, expected %d", p.ItemID, existing.Version, p.Version),
+			ErrorCode: "version_conflict",
+		}
+	}
+
+	category, err := categoryFromString(p.Item.Category)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+	fields, err := normalizeItemFields(p.Item.Fields)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	existing.Title = p.Item.Title
+	existing.Category = category
+	existing.Tags = p.Item.Tags
+	existing.Fields = fields
+	existing.Sections = normalizeSections(p.Item.Sections)
+	existing.Notes = p.Item.Notes
+	existing.Websites = normalizeWebsites(p.Item.URLs)
+
+	updated, err := client.Items().Put(ctx, existing)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to update item: %v", err))
+	}
+	return successResponse(id, updated)
+}
+
+func handleItemsDelete(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p ItemsDeleteParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for items.delete: %v", err))
+	}
+
+	if p.VaultID == "" || p.ItemID == "" {
+		return errorResponse(id, "Missing vault_id or item_id parameter")
+	}
+
+	if err := client.Items().Delete(ctx, p.VaultID, p.ItemID); err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to delete item: %v", err))
+	}
+	return successResponse(id, map[string]bool{"deleted": true})
+}
+
+func handleItemsShare(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p ItemsShareParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for items.share: %v", err))
+	}
+
+	if p.VaultID == "" || p.ItemID == "" {
+		return errorResponse(id, "Missing vault_id or item_id parameter")
+	}
+
+	item, err := client.Items().Get(ctx, p.VaultID, p.ItemID)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to get item: %v", err))
+	}
+
+	policy, err := client.Items().Shares().GetAccountPolicy(ctx, p.VaultID, p.ItemID)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to get sharing policy: %v", err))
+	}
+
+	link, err := client.Items().Shares().Create(ctx, item, policy, onepassword.ItemShareParams{
+		ExpireAfter: shareDurationFromSeconds(p.ExpiresInSeconds),
+		OneTimeOnly: p.MaxViews == 1,
+	})
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to create share link: %v", err))
+	}
+	return successResponse(id, map[string]string{"link": link})
+}
+
+// validateItemInput rejects the obviously-malformed inputs up front so
+// callers get a clear error instead of an opaque SDK failure.
+func validateItemInput(item ItemInput) error {
+	if item.Title == "" {
+		return fmt.Errorf("item.title is required")
+	}
+	if item.Category == "" {
+		return fmt.Errorf("item.category is required")
+	}
+	for _, field := range item.Fields {
+		if field.Label == "" {
+			return fmt.Errorf("every item.fields entry requires a label")
+		}
+	}
+	return nil
+}
+
+// normalizeItemInput translates the wire-level ItemInput into the SDK's
+// ItemCreateParams, the single place that maps our field-type strings
+// onto the SDK's concealed/string/otp representation.
+func normalizeItemInput(vaultID string, item ItemInput) (onepassword.ItemCreateParams, error) {
+	category, err := categoryFromString(item.Category)
+	if err != nil {
+		return onepassword.ItemCreateParams{}, err
+	}
+
+	fields, err := normalizeItemFields(item.Fields)
+	if err != nil {
+		return onepassword.ItemCreateParams{}, err
+	}
+
+	params := onepassword.ItemCreateParams{
+		VaultID:  vaultID,
+		Title:    item.Title,
+		Category: category,
+		Tags:     item.Tags,
+		Fields:   fields,
+		Sections: normalizeSections(item.Sections),
+		Websites: normalizeWebsites(item.URLs),
+	}
+	if item.Notes != "" {
+		params.Notes = &item.Notes
+	}
+	return params, nil
+}
+
+func normalizeWebsites(urls []ItemURLInput) []onepassword.Website {
+	websites := make([]onepassword.Website, 0, len(urls))
+	for _, u := range urls {
+		behavior := onepassword.AutofillBehaviorExactDomain
+		if u.Primary {
+			behavior = onepassword.AutofillBehaviorAnywhereOnWebsite
+		}
+		websites = append(websites, onepassword.Website{
+			URL:              u.Href,
+			AutofillBehavior: behavior,
+		})
+	}
+	return websites
+}
+
+func normalizeItemFields(inputs []ItemFieldInput) ([]onepassword.ItemField, error) {
+	fields := make([]onepassword.ItemField, 0, len(inputs))
+	for _, f := range inputs {
+		fieldType, err := fieldTypeFromString(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		field := onepassword.ItemField{
+			ID:        f.ID,
+			Title:     f.Label,
+			Value:     f.Value,
+			FieldType: fieldType,
+		}
+		if f.Section != "" {
+			field.SectionID = &f.Section
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// normalizeSections translates the wire-level ItemSectionInput list into
+// the SDK's ItemSection, so a field's Section can reference a section ID
+// that's actually declared on the item.
+func normalizeSections(inputs []ItemSectionInput) []onepassword.ItemSection {
+	sections := make([]onepassword.ItemSection, 0, len(inputs))
+	for _, s := range inputs {
+		sections = append(sections, onepassword.ItemSection{
+			ID:    s.ID,
+			Title: s.Label,
+		})
+	}
+	return sections
+}
+
+func fieldTypeFromString(t string) (onepassword.ItemFieldType, error) {
+	switch strings.ToLower(t) {
+	case "", "string", "text":
+		return onepassword.ItemFieldTypeText, nil
+	case "concealed", "password":
+		return onepassword.ItemFieldTypeConcealed, nil
+	case "otp":
+		return onepassword.ItemFieldTypeTOTP, nil
+	case "file":
+		return "", fmt.Errorf("field type \"file\" requires file content and isn't supported via items.create/items.update")
+	default:
+		return "", fmt.Errorf("unsupported field type %q", t)
+	}
+}
+
+func categoryFromString(c string) (onepassword.ItemCategory, error) {
+	switch strings.ToLower(c) {
+	case "login":
+		return onepassword.ItemCategoryLogin, nil
+	case "password":
+		return onepassword.ItemCategoryPassword, nil
+	case "secure_note", "securenote":
+		return onepassword.ItemCategorySecureNote, nil
+	case "server":
+		return onepassword.ItemCategoryServer, nil
+	case "database":
+		return onepassword.ItemCategoryDatabase, nil
+	case "api_credential", "apicredential":
+		return onepassword.ItemCategoryAPICredentials, nil
+	case "credit_card", "creditcard":
+		return onepassword.ItemCategoryCreditCard, nil
+	default:
+		return "", fmt.Errorf("unsupported item category %q", c)
+	}
+}
+
+// shareDurationFromSeconds rounds seconds up to the nearest duration the
+// SDK supports. A non-positive input returns nil, leaving the account's
+// default expiry in effect.
+func shareDurationFromSeconds(seconds int64) *onepassword.ItemShareDuration {
+	if seconds <= 0 {
+		return nil
+	}
+
+	var d onepassword.ItemShareDuration
+	switch {
+	case seconds <= 3600:
+		d = onepassword.ItemShareDurationOneHour
+	case seconds <= 86400:
+		d = onepassword.ItemShareDurationOneDay
+	case seconds <= 7*86400:
+		d = onepassword.ItemShareDurationSevenDays
+	case seconds <= 14*86400:
+		d = onepassword.ItemShareDurationFourteenDays
+	default:
+		d = onepassword.ItemShareDurationThirtyDays
+	}
+	return &d
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+)
+
+// KVReadParams for kv.read. Path accepts either a Vault-style KV v2 path
+// (secret/data/<vault>/<item>[/<field>]) or an op:// reference
+// (op://<vault>/<item>[/<field>]).
+type KVReadParams struct {
+	Path string `json:"path"`
+}
+
+// KVWriteParams for kv.write. Path uses the same secret/data/<vault>/<item>
+// form as KVReadParams; Data becomes the item's fields. Category is only
+// consulted when the write creates a new item (an update keeps the
+// existing item's category); it defaults to "password" when omitted.
+type KVWriteParams struct {
+	Path     string            `json:"path"`
+	Data     map[string]string `json:"data"`
+	Category string            `json:"category,omitempty"`
+}
+
+// defaultKVWriteCategory is used when a kv.write creates a new item
+// without specifying a category.
+const defaultKVWriteCategory = "password"
+
+// KVListParams for kv.list. Path is a prefix of the form
+// secret/metadata/<vault> (or secret/data/<vault>) whose items are
+// listed as child keys.
+type KVListParams struct {
+	Path string `json:"path"`
+}
+
+// kvV2Data is the shape Vault's KV v2 engine returns from a read, so
+// existing Vault-aware tooling can consume 1Password items unchanged.
+type kvV2Data struct {
+	Data     map[string]string `json:"data"`
+	Metadata kvV2Metadata      `json:"metadata"`
+}
+
+type kvV2Metadata struct {
+	Version     uint32 `json:"version"`
+	CreatedTime string `json:"created_time"`
+}
+
+func handleKVRead(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p KVReadParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for kv.read: %v", err))
+	}
+
+	if strings.HasPrefix(p.Path, "op://") {
+		_, _, field, err := parseOpReference(p.Path)
+		if err != nil {
+			return errorResponse(id, err.Error())
+		}
+		value, err := client.Secrets().Resolve(ctx, p.Path)
+		if err != nil {
+			return errorResponse(id, fmt.Sprintf("Failed to resolve %s: %v", p.Path, err))
+		}
+		if field == "" {
+			field = p.Path
+		}
+		return successResponse(id, kvV2Data{Data: map[string]string{field: value}})
+	}
+
+	vaultID, itemID, field, err := parseKVItemPath(p.Path)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	item, err := client.Items().Get(ctx, vaultID, itemID)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to get item: %v", err))
+	}
+
+	data := make(map[string]string, len(item.Fields))
+	for _, f := range item.Fields {
+		data[f.Title] = f.Value
+	}
+	if field != "" {
+		value, ok := data[field]
+		if !ok {
+			return errorResponse(id, fmt.Sprintf("Field %q not found on item %s", field, itemID))
+		}
+		data = map[string]string{field: value}
+	}
+
+	return successResponse(id, kvV2Data{
+		Data: data,
+		Metadata: kvV2Metadata{
+			Version:     item.Version,
+			CreatedTime: item.CreatedAt.Format(time.RFC3339),
+		},
+	})
+}
+
+func handleKVWrite(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p KVWriteParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for kv.write: %v", err))
+	}
+
+	vaultID, itemID, _, err := parseKVItemPath(p.Path)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	fields := make([]onepassword.ItemField, 0, len(p.Data))
+	for title, value := range p.Data {
+		fields = append(fields, onepassword.ItemField{
+			Title:     title,
+			Value:     value,
+			FieldType: onepassword.ItemFieldTypeConcealed,
+		})
+	}
+
+	existing, err := client.Items().Get(ctx, vaultID, itemID)
+	if err == nil {
+		existing.Fields = fields
+		updated, err := client.Items().Put(ctx, existing)
+		if err != nil {
+			return errorResponse(id, fmt.Sprintf("Failed to update item: %v", err))
+		}
+		return successResponse(id, updated)
+	}
+
+	categoryInput := p.Category
+	if categoryInput == "" {
+		categoryInput = defaultKVWriteCategory
+	}
+	category, err := categoryFromString(categoryInput)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	created, err := client.Items().Create(ctx, onepassword.ItemCreateParams{
+		VaultID:  vaultID,
+		Title:    itemID,
+		Category: category,
+		Fields:   fields,
+	})
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to create item: %v", err))
+	}
+	return successResponse(id, created)
+}
+
+func handleKVList(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p KVListParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return errorResponse(id, fmt.Sprintf("Invalid params for kv.list: %v", err))
+	}
+
+	vaultID, err := parseKVListPath(p.Path)
+	if err != nil {
+		return errorResponse(id, err.Error())
+	}
+
+	items, err := client.Items().List(ctx, vaultID)
+	if err != nil {
+		return errorResponse(id, fmt.Sprintf("Failed to list items: %v", err))
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Title)
+	}
+	return successResponse(id, keys)
+}
+
+// parseKVItemPath splits a "secret/data/<vault>/<item>[/<field>]" path
+// into its vault ID, item ID, and an optional field name.
+func parseKVItemPath(path string) (vaultID, itemID, field string, err error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "secret" || parts[1] != "data" {
+		return "", "", "", fmt.Errorf("invalid KV path %q, expected secret/data/<vault>/<item>[/<field>]", path)
+	}
+	vaultID, itemID = parts[2], parts[3]
+	if len(parts) >= 5 {
+		field = parts[4]
+	}
+	return vaultID, itemID, field, nil
+}
+
+// parseKVListPath extracts the vault ID from a "secret/metadata/<vault>"
+// or "secret/data/<vault>" prefix.
+func parseKVListPath(path string) (vaultID string, err error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "secret" || (parts[1] != "metadata" && parts[1] != "data") {
+		return "", fmt.Errorf("invalid KV list path %q, expected secret/metadata/<vault>", path)
+	}
+	return parts[2], nil
+}
+
+// parseOpReference splits an "op://<vault>/<item>[/<field>]" reference
+// into its vault ID, item ID, and an optional field name.
+func parseOpReference(ref string) (vaultID, itemID, field string, err error) {
+	trimmed := strings.TrimPrefix(ref, "op://")
+	if trimmed == ref {
+		return "", "", "", fmt.Errorf("invalid op:// reference %q", ref)
+	}
+	parts := strings.Split(strings.Trim(trimmed, "/"), "/")
+	if len(parts) < 2 {
+		return "", "", "", fmt.Errorf("invalid op:// reference %q, expected op://<vault>/<item>[/<field>]", ref)
+	}
+	vaultID, itemID = parts[0], parts[1]
+	if len(parts) >= 3 {
+		field = parts[2]
+	}
+	return vaultID, itemID, field, nil
+}
@@ -4,14 +4,17 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/1password/onepassword-sdk-go"
 )
 
 // Request represents the incoming JSON structure
 type Request struct {
+	ID     string          `json:"id,omitempty"`
 	Method string          `json:"method"`
 	Token  string          `json:"token"`
 	Params json.RawMessage `json:"params"`
@@ -19,60 +22,121 @@ type Request struct {
 
 // Response represents the outgoing JSON structure
 type Response struct {
-	Ok     bool        `json:"ok"`
-	Result interface{} `json:"result,omitempty"`
-	Error  string      `json:"error,omitempty"`
+	ID        string      `json:"id,omitempty"`
+	Ok        bool        `json:"ok"`
+	Result    interface{} `json:"result,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	CacheHit  bool        `json:"cache_hit,omitempty"`
+	Stale     bool        `json:"stale,omitempty"`
 }
 
-// VaultListParams (empty, no params needed)
-type VaultListParams struct{}
+// CacheOptions is embedded by request params for methods that support the
+// on-disk encrypted cache: cache_ttl (seconds) controls how long a stored
+// result is considered fresh, offline_ok lets a stale entry stand in for a
+// failed remote call, and cache_path/cache_passphrase override the
+// TAIRSEACH_CACHE_PATH / TAIRSEACH_CACHE_PASSPHRASE env vars per request.
+type CacheOptions struct {
+	CacheTTL        int64  `json:"cache_ttl,omitempty"`
+	OfflineOk       bool   `json:"offline_ok,omitempty"`
+	CachePath       string `json:"cache_path,omitempty"`
+	CachePassphrase string `json:"cache_passphrase,omitempty"`
+}
+
+// VaultListParams for vaults.list
+type VaultListParams struct {
+	CacheOptions
+}
 
 // ItemListParams for items.list
 type ItemListParams struct {
 	VaultID string `json:"vault_id"`
+	CacheOptions
 }
 
 // ItemGetParams for items.get
 type ItemGetParams struct {
 	VaultID string `json:"vault_id"`
 	ItemID  string `json:"item_id"`
+	CacheOptions
 }
 
 // ItemCreateParams for items.create
 type ItemCreateParams struct {
-	VaultID string      `json:"vault_id"`
-	Item    interface{} `json:"item"`
+	VaultID string    `json:"vault_id"`
+	Item    ItemInput `json:"item"`
 }
 
 // SecretsResolveParams for secrets.resolve
 type SecretsResolveParams struct {
 	Reference string `json:"reference"`
+	CacheOptions
 }
 
 func main() {
-	// Read single line from stdin
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		writeError("Failed to read from stdin")
+	daemon := flag.Bool("daemon", false, "run as a persistent daemon, reading newline-delimited JSON requests from stdin")
+	serve := flag.Bool("serve", false, "alias for --daemon")
+	policyFlag := flag.String("policy", "", "path to an authorization policy file (YAML or JSON); overrides "+policyPathEnv)
+	auditLogPath := flag.String("audit-log", "", "path to a JSON-lines audit log; disabled when empty")
+	auditLogMaxBytes := flag.Int64("audit-log-max-bytes", 100*1024*1024, "rotate the audit log once it would exceed this size; 0 disables size-based rotation")
+	auditLogDaily := flag.Bool("audit-log-daily", false, "also rotate the audit log once per day")
+	auditLogFsync := flag.Bool("audit-log-fsync", false, "fsync the audit log after every write")
+	flag.Parse()
+
+	policy, err := loadPolicy(*policyFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tairseach-op-helper: %v\n", err)
+		os.Exit(1)
+	}
+
+	var audit *AuditLogger
+	if *auditLogPath != "" {
+		audit, err = newAuditLogger(*auditLogPath, *auditLogMaxBytes, *auditLogDaily, *auditLogFsync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tairseach-op-helper: %v\n", err)
+			os.Exit(1)
+		}
+		defer audit.Close()
+	}
+
+	if *daemon || *serve {
+		runDaemon(os.Stdin, os.Stdout, policy, audit)
 		return
 	}
 
-	line := scanner.Text()
+	runOnce(os.Stdin, os.Stdout, policy, audit)
+}
+
+// runOnce preserves the original one-shot behavior: read a single line,
+// handle it, and exit. Kept around for callers that still spawn a fresh
+// process per call.
+func runOnce(in *os.File, out *os.File, policy *Policy, audit *AuditLogger) {
+	scanner := bufio.NewScanner(in)
+	if !scanner.Scan() {
+		writeResponseTo(out, Response{Ok: false, Error: "Failed to read from stdin"})
+		return
+	}
 
-	// Parse request
 	var req Request
-	if err := json.Unmarshal([]byte(line), &req); err != nil {
-		writeError(fmt.Sprintf("Invalid JSON: %v", err))
+	if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+		writeResponseTo(out, Response{Ok: false, Error: fmt.Sprintf("Invalid JSON: %v", err)})
 		return
 	}
 
-	// Validate token
 	if req.Token == "" {
-		writeError("Missing token")
+		writeResponseTo(out, Response{ID: req.ID, Ok: false, Error: "Missing token"})
+		return
+	}
+
+	start := time.Now()
+
+	if err := authorize(policy, req); err != nil {
+		resp := Response{ID: req.ID, Ok: false, Error: err.Error(), ErrorCode: "forbidden"}
+		logAudit(audit, policy, req, resp, start)
+		writeResponseTo(out, resp)
 		return
 	}
 
-	// Initialize 1Password client
 	ctx := context.Background()
 	client, err := onepassword.NewClient(
 		ctx,
@@ -80,135 +144,161 @@ func main() {
 		onepassword.WithIntegrationInfo("Tairseach", "0.1.0"),
 	)
 	if err != nil {
-		writeError(fmt.Sprintf("Failed to initialize 1Password client: %v", err))
+		writeResponseTo(out, Response{ID: req.ID, Ok: false, Error: fmt.Sprintf("Failed to initialize 1Password client: %v", err)})
+		return
+	}
+
+	resp := dispatch(ctx, client, req)
+	logAudit(audit, policy, req, resp, start)
+	writeResponseTo(out, resp)
+}
+
+// logAudit records one request/response pair to audit, if configured. It
+// never stores raw secret material: handleSecretsResolve/handleItemsGet
+// results are reduced to a SHA-256 fingerprint via redactSecretValue.
+func logAudit(audit *AuditLogger, policy *Policy, req Request, resp Response, start time.Time) {
+	if audit == nil {
 		return
 	}
 
-	// Route method
+	salt := ""
+	if policy != nil {
+		salt = policy.Salt
+	}
+	vaultID, itemID := extractVaultAndItem(req.Params)
+	fingerprint, redacted := redactSecretValue(req.Method, resp.Result)
+
+	value := ""
+	if redacted {
+		value = redactedPlaceholder
+	}
+
+	err := audit.Log(AuditRecord{
+		Timestamp:        time.Now(),
+		Method:           req.Method,
+		TokenFingerprint: fingerprintToken(req.Token, salt),
+		VaultID:          vaultID,
+		ItemID:           itemID,
+		LatencyMS:        time.Since(start).Milliseconds(),
+		Ok:               resp.Ok,
+		Error:            resp.Error,
+		ErrorCode:        resp.ErrorCode,
+		Redacted:         redacted,
+		Value:            value,
+		ValueFingerprint: fingerprint,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tairseach-op-helper: %v\n", err)
+	}
+}
+
+// dispatch routes a single request to its handler and returns the response,
+// rather than writing directly to stdout, so it can be reused by both the
+// one-shot and daemon code paths.
+func dispatch(ctx context.Context, client *onepassword.Client, req Request) Response {
 	switch req.Method {
 	case "vaults.list":
-		handleVaultsList(ctx, client)
+		return handleVaultsList(ctx, client, req.ID, req.Params)
 	case "items.list":
-		handleItemsList(ctx, client, req.Params)
+		return handleItemsList(ctx, client, req.ID, req.Params)
 	case "items.get":
-		handleItemsGet(ctx, client, req.Params)
+		return handleItemsGet(ctx, client, req.ID, req.Params)
 	case "items.create":
-		handleItemsCreate(ctx, client, req.Params)
+		return handleItemsCreate(ctx, client, req.ID, req.Params)
+	case "items.update":
+		return handleItemsUpdate(ctx, client, req.ID, req.Params)
+	case "items.delete":
+		return handleItemsDelete(ctx, client, req.ID, req.Params)
+	case "items.share":
+		return handleItemsShare(ctx, client, req.ID, req.Params)
 	case "secrets.resolve":
-		handleSecretsResolve(ctx, client, req.Params)
+		return handleSecretsResolve(ctx, client, req.ID, req.Params)
+	case "kv.read":
+		return handleKVRead(ctx, client, req.ID, req.Params)
+	case "kv.write":
+		return handleKVWrite(ctx, client, req.ID, req.Params)
+	case "kv.list":
+		return handleKVList(ctx, client, req.ID, req.Params)
 	default:
-		writeError(fmt.Sprintf("Unknown method: %s", req.Method))
+		return Response{ID: req.ID, Ok: false, Error: fmt.Sprintf("Unknown method: %s", req.Method)}
 	}
 }
 
-func handleVaultsList(ctx context.Context, client *onepassword.Client) {
-	vaults, err := client.Vaults().List(ctx)
-	if err != nil {
-		writeError(fmt.Sprintf("Failed to list vaults: %v", err))
-		return
+func handleVaultsList(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
+	var p VaultListParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return errorResponse(id, fmt.Sprintf("Invalid params for vaults.list: %v", err))
+		}
 	}
-	writeSuccess(vaults)
+
+	return cachedCall(id, "vaults.list", p.CacheOptions, []string{}, "Failed to list vaults", func() (interface{}, error) {
+		return client.Vaults().List(ctx)
+	})
 }
 
-func handleItemsList(ctx context.Context, client *onepassword.Client, params json.RawMessage) {
+func handleItemsList(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
 	var p ItemListParams
 	if err := json.Unmarshal(params, &p); err != nil {
-		writeError(fmt.Sprintf("Invalid params for items.list: %v", err))
-		return
+		return errorResponse(id, fmt.Sprintf("Invalid params for items.list: %v", err))
 	}
 
 	if p.VaultID == "" {
-		writeError("Missing vault_id parameter")
-		return
+		return errorResponse(id, "Missing vault_id parameter")
 	}
 
-	items, err := client.Items().List(ctx, p.VaultID)
-	if err != nil {
-		writeError(fmt.Sprintf("Failed to list items: %v", err))
-		return
-	}
-	writeSuccess(items)
+	return cachedCall(id, "items.list", p.CacheOptions, []string{p.VaultID}, "Failed to list items", func() (interface{}, error) {
+		return client.Items().List(ctx, p.VaultID)
+	})
 }
 
-func handleItemsGet(ctx context.Context, client *onepassword.Client, params json.RawMessage) {
+func handleItemsGet(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
 	var p ItemGetParams
 	if err := json.Unmarshal(params, &p); err != nil {
-		writeError(fmt.Sprintf("Invalid params for items.get: %v", err))
-		return
+		return errorResponse(id, fmt.Sprintf("Invalid params for items.get: %v", err))
 	}
 
 	if p.VaultID == "" || p.ItemID == "" {
-		writeError("Missing vault_id or item_id parameter")
-		return
+		return errorResponse(id, "Missing vault_id or item_id parameter")
 	}
 
-	item, err := client.Items().Get(ctx, p.VaultID, p.ItemID)
-	if err != nil {
-		writeError(fmt.Sprintf("Failed to get item: %v", err))
-		return
-	}
-	writeSuccess(item)
+	return cachedCall(id, "items.get", p.CacheOptions, []string{p.VaultID, p.ItemID}, "Failed to get item", func() (interface{}, error) {
+		return client.Items().Get(ctx, p.VaultID, p.ItemID)
+	})
 }
 
-func handleItemsCreate(ctx context.Context, client *onepassword.Client, params json.RawMessage) {
-	var p ItemCreateParams
-	if err := json.Unmarshal(params, &p); err != nil {
-		writeError(fmt.Sprintf("Invalid params for items.create: %v", err))
-		return
-	}
-
-	if p.VaultID == "" {
-		writeError("Missing vault_id parameter")
-		return
-	}
-
-	// Note: The SDK's actual item creation method may differ
-	// This is a placeholder - adjust based on the actual SDK API
-	writeError("items.create not fully implemented - SDK integration needed")
-}
-
-func handleSecretsResolve(ctx context.Context, client *onepassword.Client, params json.RawMessage) {
+func handleSecretsResolve(ctx context.Context, client *onepassword.Client, id string, params json.RawMessage) Response {
 	var p SecretsResolveParams
 	if err := json.Unmarshal(params, &p); err != nil {
-		writeError(fmt.Sprintf("Invalid params for secrets.resolve: %v", err))
-		return
+		return errorResponse(id, fmt.Sprintf("Invalid params for secrets.resolve: %v", err))
 	}
 
 	if p.Reference == "" {
-		writeError("Missing reference parameter")
-		return
+		return errorResponse(id, "Missing reference parameter")
 	}
 
-	secret, err := client.Secrets().Resolve(ctx, p.Reference)
-	if err != nil {
-		writeError(fmt.Sprintf("Failed to resolve secret: %v", err))
-		return
-	}
-	writeSuccess(map[string]string{"value": secret})
+	return cachedCall(id, "secrets.resolve", p.CacheOptions, []string{p.Reference}, "Failed to resolve secret", func() (interface{}, error) {
+		secret, err := client.Secrets().Resolve(ctx, p.Reference)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"value": secret}, nil
+	})
 }
 
-func writeSuccess(result interface{}) {
-	resp := Response{
-		Ok:     true,
-		Result: result,
-	}
-	writeResponse(resp)
+func successResponse(id string, result interface{}) Response {
+	return Response{ID: id, Ok: true, Result: result}
 }
 
-func writeError(message string) {
-	resp := Response{
-		Ok:    false,
-		Error: message,
-	}
-	writeResponse(resp)
+func errorResponse(id string, message string) Response {
+	return Response{ID: id, Ok: false, Error: message}
 }
 
-func writeResponse(resp Response) {
+func writeResponseTo(out *os.File, resp Response) {
 	data, err := json.Marshal(resp)
 	if err != nil {
-		// Last resort - write error directly
-		fmt.Fprintf(os.Stdout, `{"ok":false,"error":"Failed to marshal response: %v"}`+"\n", err)
+		fmt.Fprintf(out, `{"ok":false,"error":"Failed to marshal response: %v"}`+"\n", err)
 		return
 	}
-	fmt.Fprintf(os.Stdout, "%s\n", data)
+	fmt.Fprintf(out, "%s\n", data)
 }
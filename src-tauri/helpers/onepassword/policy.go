@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v3"
+)
+
+// policyPathEnv names the env var carrying the policy file path, used
+// when --policy isn't passed on the command line.
+const policyPathEnv = "TAIRSEACH_POLICY"
+
+// PolicyRule gates one (token, method, vault, item) combination. Method
+// and Scope are both optional; when set they must match the incoming
+// request's method (exact) or its derived scope ("read"/"write"/"list"),
+// respectively. VaultIDGlob/ItemIDGlob default to "*" when empty.
+type PolicyRule struct {
+	TokenFingerprint string `yaml:"token_fingerprint" json:"token_fingerprint"`
+	Method           string `yaml:"method,omitempty" json:"method,omitempty"`
+	Scope            string `yaml:"scope,omitempty" json:"scope,omitempty"`
+	VaultIDGlob      string `yaml:"vault_id_glob,omitempty" json:"vault_id_glob,omitempty"`
+	ItemIDGlob       string `yaml:"item_id_glob,omitempty" json:"item_id_glob,omitempty"`
+	Effect           string `yaml:"effect" json:"effect"` // "allow" or "deny"
+}
+
+// Policy is the top-level shape of a --policy / TAIRSEACH_POLICY file.
+// Salt is mixed into every token fingerprint so the file can be compared
+// against logs/audit records without ever storing a raw token.
+type Policy struct {
+	Salt  string       `yaml:"salt" json:"salt"`
+	Rules []PolicyRule `yaml:"rules" json:"rules"`
+}
+
+// loadPolicy reads and parses a policy file. An empty path means no
+// policy is configured, in which case every request is allowed.
+func loadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		path = os.Getenv(policyPathEnv)
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &policy)
+	default:
+		err = json.Unmarshal(data, &policy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// fingerprintToken returns a salted SHA-256 fingerprint of token so
+// policy files and audit logs never need to store it in plaintext.
+func fingerprintToken(token, salt string) string {
+	sum := sha256.Sum256([]byte(salt + token))
+	return hex.EncodeToString(sum[:])
+}
+
+// methodScope maps a method onto the coarse read/write/list scopes used
+// by PolicyRule.Scope.
+func methodScope(method string) string {
+	switch method {
+	case "items.list", "kv.list":
+		return "list"
+	case "vaults.list":
+		return "list"
+	case "items.get", "secrets.resolve", "kv.read":
+		return "read"
+	case "items.create", "items.update", "items.delete", "items.share", "kv.write":
+		return "write"
+	default:
+		return ""
+	}
+}
+
+// authorize evaluates req against policy and returns an error describing
+// the denial, or nil if the request is allowed. A nil policy allows
+// everything (authorization is opt-in). When a policy is configured,
+// rules are evaluated in order and the last matching rule wins; a
+// request that matches no rule is denied by default.
+func authorize(policy *Policy, req Request) error {
+	if policy == nil {
+		return nil
+	}
+
+	fp := fingerprintToken(req.Token, policy.Salt)
+	scope := methodScope(req.Method)
+	vaultID, itemID := extractVaultAndItem(req.Params)
+
+	matched := false
+	allowed := false
+	for _, rule := range policy.Rules {
+		if rule.TokenFingerprint != "*" && rule.TokenFingerprint != fp {
+			continue
+		}
+		if rule.Method != "" && rule.Method != req.Method {
+			continue
+		}
+		if rule.Scope != "" && rule.Scope != scope {
+			continue
+		}
+		if !globMatches(rule.VaultIDGlob, vaultID) || !globMatches(rule.ItemIDGlob, itemID) {
+			continue
+		}
+
+		matched = true
+		allowed = strings.EqualFold(rule.Effect, "allow")
+	}
+
+	if !matched || !allowed {
+		return fmt.Errorf("denied by policy")
+	}
+	return nil
+}
+
+// globMatches reports whether value matches pattern. An empty pattern
+// matches anything, including an empty value.
+func globMatches(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	g, err := glob.Compile(pattern)
+	if err != nil {
+		return false
+	}
+	return g.Match(value)
+}
+
+// extractVaultAndItem best-effort pulls vault_id/item_id out of a
+// request's params, regardless of which method-specific struct they
+// belong to. This feeds PolicyRule.VaultIDGlob/ItemIDGlob matching, so
+// every call shape that can name a vault or item — including the
+// secrets.resolve "reference" field and op:// references taken by
+// kv.read — must be recognized here, or a vault-scoped rule silently
+// never matches it.
+func extractVaultAndItem(params json.RawMessage) (vaultID, itemID string) {
+	var generic struct {
+		VaultID   string `json:"vault_id"`
+		ItemID    string `json:"item_id"`
+		Path      string `json:"path"`
+		Reference string `json:"reference"`
+	}
+	if err := json.Unmarshal(params, &generic); err != nil {
+		return "", ""
+	}
+	if generic.VaultID != "" || generic.ItemID != "" {
+		return generic.VaultID, generic.ItemID
+	}
+	if strings.HasPrefix(generic.Path, "op://") {
+		if v, i, _, err := parseOpReference(generic.Path); err == nil {
+			return v, i
+		}
+		return "", ""
+	}
+	if v, i, _, err := parseKVItemPath(generic.Path); err == nil {
+		return v, i
+	}
+	// kv.list paths (secret/metadata/<vault> or secret/data/<vault>) have
+	// no item segment at all.
+	if v, err := parseKVListPath(generic.Path); err == nil {
+		return v, ""
+	}
+	if strings.HasPrefix(generic.Reference, "op://") {
+		if v, i, _, err := parseOpReference(generic.Reference); err == nil {
+			return v, i
+		}
+	}
+	return "", ""
+}